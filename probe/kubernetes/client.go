@@ -0,0 +1,688 @@
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	csisnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	csisnapshotclientset "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned"
+	volumesnapshotv1 "github.com/openebs/external-storage/snapshot/pkg/apis/volumesnapshot/v1"
+	openebssnapshotclientset "github.com/openebs/external-storage/snapshot/pkg/client/clientset/versioned"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/kubectl/pkg/describe"
+
+	"github.com/weaveworks/scope/report"
+)
+
+// Client describes the interface the kubernetes probe uses to talk to a
+// cluster. Control handlers go through it rather than client-go directly so
+// tests can swap in a fake.
+type Client interface {
+	WalkPods(f func(Pod) error) error
+	WalkDeployments(f func(Deployment) error) error
+	WalkServices(f func(Service) error) error
+	WalkDaemonSets(f func(DaemonSet) error) error
+	WalkCronJobs(f func(CronJob) error) error
+	WalkStatefulSets(f func(StatefulSet) error) error
+	WalkStorageClasses(f func(StorageClass) error) error
+	WalkPersistentVolumes(f func(PersistentVolume) error) error
+	WalkPersistentVolumeClaims(f func(PersistentVolumeClaim) error) error
+	WalkVolumeSnapshots(f func(VolumeSnapshot) error) error
+	WalkVolumeSnapshotClasses(f func(VolumeSnapshotClass) error) error
+	WalkVolumeSnapshotContents(f func(VolumeSnapshotContent) error) error
+	WalkNodes(f func(HostNode) error) error
+
+	GetLogs(namespaceID, podID, containerName string, opts LogsOptions) (io.ReadCloser, error)
+	ExecPod(namespaceID, podID, containerName, command string) (remotecommand.Executor, error)
+	Describe(namespaceID, resourceID string, groupKind schema.GroupKind) (io.ReadCloser, error)
+
+	DeletePod(namespaceID, podID string) error
+	CloneVolumeSnapshot(namespaceID, volumeSnapshotID, persistentVolumeClaimID, capacity string) error
+	CreateVolumeSnapshot(namespaceID, persistentVolumeClaimID, capacity string) error
+	RestoreVolumeSnapshot(namespaceID, volumeSnapshotID, newPVCName, storageClass, capacity string) error
+	DeleteVolumeSnapshot(namespaceID, volumeSnapshotID string) error
+
+	ScaleUp(resourceKind, namespaceID, id string) error
+	ScaleDown(resourceKind, namespaceID, id string) error
+	RestartDeployment(namespaceID, id, timestamp string) error
+	PauseDeployment(namespaceID, id string) error
+	ResumeDeployment(namespaceID, id string) error
+	RollbackDeployment(namespaceID, id string) error
+
+	CordonNode(nodeID string) error
+	UncordonNode(nodeID string) error
+	DrainNode(nodeID string) (io.ReadCloser, error)
+}
+
+// client is the clientset-backed implementation of Client.
+type client struct {
+	config           *rest.Config
+	client           kubernetes.Interface
+	csiSnapshot      csisnapshotclientset.Interface
+	openebsSnapshot  openebssnapshotclientset.Interface
+	discovery        discovery.DiscoveryInterface
+	snapshotAPIGroup SnapshotAPIGroup
+}
+
+// NewClient returns a new Client, detecting which VolumeSnapshot CRD group
+// the cluster has installed once up front.
+func NewClient(config *rest.Config, clientset kubernetes.Interface, csiSnapshot csisnapshotclientset.Interface, openebsSnapshot openebssnapshotclientset.Interface, disco discovery.DiscoveryInterface) (Client, error) {
+	snapshotAPIGroup, err := DetectSnapshotAPIGroup(disco)
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		config:           config,
+		client:           clientset,
+		csiSnapshot:      csiSnapshot,
+		openebsSnapshot:  openebsSnapshot,
+		discovery:        disco,
+		snapshotAPIGroup: snapshotAPIGroup,
+	}, nil
+}
+
+func (c *client) WalkPods(f func(Pod) error) error {
+	pods, err := c.client.CoreV1().Pods(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		if err := f(NewPod(&pods.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkDeployments(f func(Deployment) error) error {
+	deployments, err := c.client.AppsV1().Deployments(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		if err := f(NewDeployment(&deployments.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkServices(f func(Service) error) error {
+	services, err := c.client.CoreV1().Services(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range services.Items {
+		if err := f(NewService(&services.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkDaemonSets(f func(DaemonSet) error) error {
+	daemonSets, err := c.client.AppsV1().DaemonSets(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range daemonSets.Items {
+		if err := f(NewDaemonSet(&daemonSets.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkCronJobs(f func(CronJob) error) error {
+	cronJobs, err := c.client.BatchV1().CronJobs(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range cronJobs.Items {
+		if err := f(NewCronJob(&cronJobs.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkStatefulSets(f func(StatefulSet) error) error {
+	statefulSets, err := c.client.AppsV1().StatefulSets(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		if err := f(NewStatefulSet(&statefulSets.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkStorageClasses(f func(StorageClass) error) error {
+	storageClasses, err := c.client.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range storageClasses.Items {
+		if err := f(NewStorageClass(&storageClasses.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkPersistentVolumes(f func(PersistentVolume) error) error {
+	pvs, err := c.client.CoreV1().PersistentVolumes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range pvs.Items {
+		if err := f(NewPersistentVolume(&pvs.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkPersistentVolumeClaims(f func(PersistentVolumeClaim) error) error {
+	pvcs, err := c.client.CoreV1().PersistentVolumeClaims(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range pvcs.Items {
+		if err := f(NewPersistentVolumeClaim(&pvcs.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkVolumeSnapshots lists VolumeSnapshots from whichever CRD group
+// DetectSnapshotAPIGroup found installed on the cluster.
+func (c *client) WalkVolumeSnapshots(f func(VolumeSnapshot) error) error {
+	switch c.snapshotAPIGroup {
+	case SnapshotAPIGroupCSIV1:
+		snapshots, err := c.csiSnapshot.SnapshotV1().VolumeSnapshots(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range snapshots.Items {
+			if err := f(NewVolumeSnapshot(csiSnapshotSource{VolumeSnapshot: &snapshots.Items[i]})); err != nil {
+				return err
+			}
+		}
+		return nil
+	case SnapshotAPIGroupCSIV1Beta1:
+		snapshots, err := c.csiSnapshot.SnapshotV1beta1().VolumeSnapshots(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range snapshots.Items {
+			if err := f(NewVolumeSnapshot(csiSnapshotV1Beta1Source{VolumeSnapshot: &snapshots.Items[i]})); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		snapshots, err := c.openebsSnapshot.VolumesnapshotV1().VolumeSnapshots(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range snapshots.Items {
+			if err := f(NewVolumeSnapshot(openEBSSnapshotSource{VolumeSnapshot: &snapshots.Items[i]})); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WalkVolumeSnapshotClasses lists VolumeSnapshotClasses from whichever CRD
+// group DetectSnapshotAPIGroup found installed on the cluster. The legacy
+// openebs snapshot-provisioner API predates VolumeSnapshotClass, so there is
+// nothing to walk for it.
+func (c *client) WalkVolumeSnapshotClasses(f func(VolumeSnapshotClass) error) error {
+	if c.snapshotAPIGroup == SnapshotAPIGroupOpenEBS {
+		return nil
+	}
+	storageClassesByDriver, err := c.storageClassNamesByProvisioner()
+	if err != nil {
+		return err
+	}
+	if c.snapshotAPIGroup == SnapshotAPIGroupCSIV1Beta1 {
+		classes, err := c.csiSnapshot.SnapshotV1beta1().VolumeSnapshotClasses().List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range classes.Items {
+			class := &classes.Items[i]
+			v1Class := &csisnapshotv1.VolumeSnapshotClass{
+				ObjectMeta:     class.ObjectMeta,
+				Driver:         class.Driver,
+				DeletionPolicy: csisnapshotv1.DeletionPolicy(class.DeletionPolicy),
+			}
+			if err := f(NewVolumeSnapshotClass(v1Class, storageClassesByDriver[class.Driver])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	classes, err := c.csiSnapshot.SnapshotV1().VolumeSnapshotClasses().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if err := f(NewVolumeSnapshotClass(class, storageClassesByDriver[class.Driver])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageClassNamesByProvisioner indexes every StorageClass by its
+// Provisioner, so a VolumeSnapshotClass can look up the StorageClasses that
+// share its Driver.
+func (c *client) storageClassNamesByProvisioner() (map[string][]string, error) {
+	storageClasses, err := c.client.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	byProvisioner := map[string][]string{}
+	for _, storageClass := range storageClasses.Items {
+		byProvisioner[storageClass.Provisioner] = append(byProvisioner[storageClass.Provisioner], storageClass.Name)
+	}
+	return byProvisioner, nil
+}
+
+// WalkVolumeSnapshotContents lists VolumeSnapshotContents from whichever CRD
+// group DetectSnapshotAPIGroup found installed on the cluster. The legacy
+// openebs snapshot-provisioner API predates VolumeSnapshotContent, so there
+// is nothing to walk for it.
+func (c *client) WalkVolumeSnapshotContents(f func(VolumeSnapshotContent) error) error {
+	if c.snapshotAPIGroup == SnapshotAPIGroupOpenEBS {
+		return nil
+	}
+	if c.snapshotAPIGroup == SnapshotAPIGroupCSIV1Beta1 {
+		contents, err := c.csiSnapshot.SnapshotV1beta1().VolumeSnapshotContents().List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range contents.Items {
+			content := &contents.Items[i]
+			v1Content := &csisnapshotv1.VolumeSnapshotContent{
+				ObjectMeta: content.ObjectMeta,
+				Spec: csisnapshotv1.VolumeSnapshotContentSpec{
+					VolumeSnapshotRef:       content.Spec.VolumeSnapshotRef,
+					VolumeSnapshotClassName: content.Spec.VolumeSnapshotClassName,
+				},
+			}
+			if err := f(NewVolumeSnapshotContent(v1Content)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	contents, err := c.csiSnapshot.SnapshotV1().VolumeSnapshotContents().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range contents.Items {
+		if err := f(NewVolumeSnapshotContent(&contents.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) WalkNodes(f func(HostNode) error) error {
+	nodes, err := c.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range nodes.Items {
+		if err := f(NewHostNode(&nodes.Items[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLogs streams one container's logs, honouring follow/tailLines/
+// sinceSeconds/previous the same way `kubectl logs` does.
+func (c *client) GetLogs(namespaceID, podID, containerName string, opts LogsOptions) (io.ReadCloser, error) {
+	logOpts := &apiv1.PodLogOptions{
+		Container: containerName,
+		Follow:    opts.Follow,
+		Previous:  opts.Previous,
+	}
+	if opts.TailLines > 0 {
+		logOpts.TailLines = &opts.TailLines
+	}
+	if opts.SinceSeconds > 0 {
+		logOpts.SinceSeconds = &opts.SinceSeconds
+	}
+	return c.client.CoreV1().Pods(namespaceID).GetLogs(podID, logOpts).Stream(context.Background())
+}
+
+// ExecPod opens an interactive shell in a pod's container over SPDY.
+func (c *client) ExecPod(namespaceID, podID, containerName, command string) (remotecommand.Executor, error) {
+	req := c.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podID).
+		Namespace(namespaceID).
+		SubResource("exec").
+		VersionedParams(&apiv1.PodExecOptions{
+			Container: containerName,
+			Command:   []string{command},
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+	return remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
+}
+
+// Describe returns a `kubectl describe`-style dump of a resource, produced
+// in-process by the same describer library (with events) kubectl uses.
+func (c *client) Describe(namespaceID, resourceID string, groupKind schema.GroupKind) (io.ReadCloser, error) {
+	describer, ok := describerFor(groupKind, c.config)
+	if !ok {
+		return nil, fmt.Errorf("no describer registered for %s", groupKind)
+	}
+	output, err := describer.Describe(namespaceID, resourceID, describe.DescriberSettings{ShowEvents: true})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(output)), nil
+}
+
+// CloneVolumeSnapshot provisions a new PVC from an openebs
+// snapshot-provisioner VolumeSnapshot by pointing the PVC's
+// snapshot.alpha.kubernetes.io/snapshot annotation at it.
+func (c *client) CloneVolumeSnapshot(namespaceID, volumeSnapshotID, persistentVolumeClaimID, capacity string) error {
+	snapshot, err := c.openebsSnapshot.VolumesnapshotV1().VolumeSnapshots(namespaceID).Get(context.Background(), volumeSnapshotID, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pvc := &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      persistentVolumeClaimID,
+			Namespace: namespaceID,
+			Annotations: map[string]string{
+				"snapshot.alpha.kubernetes.io/snapshot": volumeSnapshotID,
+			},
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			AccessModes: []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce},
+			Resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{
+					apiv1.ResourceStorage: resource.MustParse(capacity),
+				},
+			},
+			StorageClassName: &snapshot.Annotations[storageClassAnnotationKey],
+		},
+	}
+	_, err = c.client.CoreV1().PersistentVolumeClaims(namespaceID).Create(context.Background(), pvc, metav1.CreateOptions{})
+	return err
+}
+
+// CreateVolumeSnapshot takes an openebs snapshot-provisioner snapshot of an
+// existing PVC.
+func (c *client) CreateVolumeSnapshot(namespaceID, persistentVolumeClaimID, capacity string) error {
+	snapshot := &volumesnapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: persistentVolumeClaimID + "-snapshot-",
+			Namespace:    namespaceID,
+		},
+		Spec: volumesnapshotv1.VolumeSnapshotSpec{
+			PersistentVolumeClaimName: persistentVolumeClaimID,
+		},
+	}
+	_, err := c.openebsSnapshot.VolumesnapshotV1().VolumeSnapshots(namespaceID).Create(context.Background(), snapshot, metav1.CreateOptions{})
+	return err
+}
+
+// RestoreVolumeSnapshot provisions a new PVC from a CSI VolumeSnapshot's
+// dataSource, as an alternative to CloneVolumeSnapshot for CSI-backed
+// snapshots.
+func (c *client) RestoreVolumeSnapshot(namespaceID, volumeSnapshotID, newPVCName, storageClass, capacity string) error {
+	quantity, err := resource.ParseQuantity(capacity)
+	if err != nil {
+		return fmt.Errorf("restore %s/%s: invalid capacity %q: %w", namespaceID, volumeSnapshotID, capacity, err)
+	}
+	apiGroup := "snapshot.storage.k8s.io"
+	pvc := &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newPVCName,
+			Namespace: namespaceID,
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			AccessModes: []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce},
+			Resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{
+					apiv1.ResourceStorage: quantity,
+				},
+			},
+			DataSource: &apiv1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     volumeSnapshotID,
+			},
+		},
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	_, err = c.client.CoreV1().PersistentVolumeClaims(namespaceID).Create(context.Background(), pvc, metav1.CreateOptions{})
+	return err
+}
+
+// DeleteVolumeSnapshot deletes a VolumeSnapshot from whichever CRD group the
+// cluster has installed.
+func (c *client) DeleteVolumeSnapshot(namespaceID, volumeSnapshotID string) error {
+	switch c.snapshotAPIGroup {
+	case SnapshotAPIGroupCSIV1:
+		return c.csiSnapshot.SnapshotV1().VolumeSnapshots(namespaceID).Delete(context.Background(), volumeSnapshotID, metav1.DeleteOptions{})
+	case SnapshotAPIGroupCSIV1Beta1:
+		return c.csiSnapshot.SnapshotV1beta1().VolumeSnapshots(namespaceID).Delete(context.Background(), volumeSnapshotID, metav1.DeleteOptions{})
+	default:
+		return c.openebsSnapshot.VolumesnapshotV1().VolumeSnapshots(namespaceID).Delete(context.Background(), volumeSnapshotID, metav1.DeleteOptions{})
+	}
+}
+
+func (c *client) DeletePod(namespaceID, podID string) error {
+	return c.client.CoreV1().Pods(namespaceID).Delete(context.Background(), podID, metav1.DeleteOptions{})
+}
+
+func (c *client) ScaleUp(resourceKind, namespaceID, id string) error {
+	return c.scale(resourceKind, namespaceID, id, 1)
+}
+
+func (c *client) ScaleDown(resourceKind, namespaceID, id string) error {
+	return c.scale(resourceKind, namespaceID, id, -1)
+}
+
+func (c *client) scale(resourceKind, namespaceID, id string, delta int32) error {
+	deployments := c.client.AppsV1().Deployments(namespaceID)
+	deployment, err := deployments.Get(context.Background(), id, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	replicas += delta
+	if replicas < 0 {
+		replicas = 0
+	}
+	deployment.Spec.Replicas = &replicas
+	_, err = deployments.Update(context.Background(), deployment, metav1.UpdateOptions{})
+	return err
+}
+
+// RestartDeployment triggers a rolling restart by patching
+// spec.template.metadata.annotations["kubectl.kubernetes.io/restartedAt"].
+func (c *client) RestartDeployment(namespaceID, id, timestamp string) error {
+	patch := fmt.Sprintf(
+		`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`,
+		timestamp,
+	)
+	_, err := c.client.AppsV1().Deployments(namespaceID).Patch(context.Background(), id, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+func (c *client) PauseDeployment(namespaceID, id string) error {
+	return c.setDeploymentPaused(namespaceID, id, true)
+}
+
+func (c *client) ResumeDeployment(namespaceID, id string) error {
+	return c.setDeploymentPaused(namespaceID, id, false)
+}
+
+func (c *client) setDeploymentPaused(namespaceID, id string, paused bool) error {
+	patch := fmt.Sprintf(`{"spec":{"paused":%t}}`, paused)
+	_, err := c.client.AppsV1().Deployments(namespaceID).Patch(context.Background(), id, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// RollbackDeployment rolls a deployment's spec.template back to the
+// ReplicaSet that preceded its current revision. apps/v1 dropped the
+// DeploymentRollback subresource, so this is done client-side.
+func (c *client) RollbackDeployment(namespaceID, id string) error {
+	deployments := c.client.AppsV1().Deployments(namespaceID)
+	deployment, err := deployments.Get(context.Background(), id, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	currentRevision := deployment.Annotations["deployment.kubernetes.io/revision"]
+
+	replicaSets, err := c.client.AppsV1().ReplicaSets(namespaceID).List(context.Background(), metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(deployment.Spec.Selector),
+	})
+	if err != nil {
+		return err
+	}
+
+	var previousRS *appsReplicaSet
+	var previousRevision int64
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		revision := rs.Annotations["deployment.kubernetes.io/revision"]
+		if revision == "" || revision == currentRevision {
+			continue
+		}
+		// Revisions are monotonically increasing integers, so compare them
+		// numerically - a lexicographic comparison picks "9" over "10".
+		revisionNum, err := strconv.ParseInt(revision, 10, 64)
+		if err != nil {
+			continue
+		}
+		if previousRS == nil || revisionNum > previousRevision {
+			previousRS = &appsReplicaSet{revision: revision, template: rs.Spec.Template}
+			previousRevision = revisionNum
+		}
+	}
+	if previousRS == nil {
+		return fmt.Errorf("no previous revision found for deployment %s/%s", namespaceID, id)
+	}
+
+	deployment.Spec.Template = previousRS.template
+	_, err = deployments.Update(context.Background(), deployment, metav1.UpdateOptions{})
+	return err
+}
+
+// appsReplicaSet holds just enough of a candidate rollback target to pick the
+// highest-revisioned one preceding the deployment's current revision.
+type appsReplicaSet struct {
+	revision string
+	template apiv1.PodTemplateSpec
+}
+
+func (c *client) CordonNode(nodeID string) error {
+	return c.setNodeUnschedulable(nodeID, true)
+}
+
+func (c *client) UncordonNode(nodeID string) error {
+	return c.setNodeUnschedulable(nodeID, false)
+}
+
+func (c *client) setNodeUnschedulable(nodeID string, unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err := c.client.CoreV1().Nodes().Patch(context.Background(), nodeID, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// DrainNode cordons a node, then evicts its non-mirror, non-DaemonSet pods
+// one at a time via the eviction subresource, streaming a progress line per
+// pod back to the caller.
+func (c *client) DrainNode(nodeID string) (io.ReadCloser, error) {
+	if err := c.CordonNode(nodeID); err != nil {
+		return nil, err
+	}
+
+	pods, err := c.client.CoreV1().Pods(apiv1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer pw.Close()
+		writer := bufio.NewWriter(pw)
+		defer writer.Flush()
+		for _, pod := range pods.Items {
+			if isMirrorPod(pod) || isDaemonSetPod(pod) {
+				continue
+			}
+			err := c.client.PolicyV1().Evictions(pod.Namespace).Evict(context.Background(), &policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			})
+			if err != nil {
+				fmt.Fprintf(writer, "evict %s/%s: %v\n", pod.Namespace, pod.Name, err)
+				writer.Flush()
+				continue
+			}
+			fmt.Fprintf(writer, "evicted %s/%s\n", pod.Namespace, pod.Name)
+			writer.Flush()
+		}
+	}()
+	return pr, nil
+}
+
+// describerFor resolves a kubectl Describer for the given resource kind.
+func describerFor(groupKind schema.GroupKind, config *rest.Config) (describe.Describer, bool) {
+	return describe.DescriberFor(groupKind, config)
+}
+
+func isMirrorPod(pod apiv1.Pod) bool {
+	_, ok := pod.Annotations[apiv1.MirrorPodAnnotationKey]
+	return ok
+}
+
+func isDaemonSetPod(pod apiv1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
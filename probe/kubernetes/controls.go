@@ -3,53 +3,142 @@ package kubernetes
 import (
 	"io"
 	"io/ioutil"
+	"time"
 
 	"github.com/weaveworks/scope/common/xfer"
 	"github.com/weaveworks/scope/probe/controls"
 	"github.com/weaveworks/scope/report"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/remotecommand"
 )
 
 // Control IDs used by the kubernetes integration.
 const (
-	CloneVolumeSnapshot  = report.KubernetesCloneVolumeSnapshot
-	CreateVolumeSnapshot = report.KubernetesCreateVolumeSnapshot
-	GetLogs              = report.KubernetesGetLogs
-	DescribePod          = report.KubernetesDescribePod
-	DescribeService      = report.KubernetesDescribeService
-	DescribeCronJob      = report.KubernetesCronjob
-	DescribeDeployment   = report.KubernetesDescribeDeployment
-	DescribeDaemonSet    = report.KubernetesDescribeDaemonSet
-	DescribePVC          = report.KubernetesDescribePVC
-	DescribePV           = report.KubernetesDescribePV
-	DescribeSC           = report.KubernetesDescribeSC
-	DescribeStatefulSet  = report.KubernetesDescribeStatefulSet
-	DeletePod            = report.KubernetesDeletePod
-	DeleteVolumeSnapshot = report.KubernetesDeleteVolumeSnapshot
-	ScaleUp              = report.KubernetesScaleUp
-	ScaleDown            = report.KubernetesScaleDown
+	CloneVolumeSnapshot   = report.KubernetesCloneVolumeSnapshot
+	CreateVolumeSnapshot  = report.KubernetesCreateVolumeSnapshot
+	RestoreVolumeSnapshot = report.KubernetesRestoreVolumeSnapshot
+	GetLogs               = report.KubernetesGetLogs
+	ExecPod               = report.KubernetesExecPod
+	DescribePod           = report.KubernetesDescribePod
+	DescribeService       = report.KubernetesDescribeService
+	DescribeCronJob       = report.KubernetesCronjob
+	DescribeDeployment    = report.KubernetesDescribeDeployment
+	DescribeDaemonSet     = report.KubernetesDescribeDaemonSet
+	DescribePVC           = report.KubernetesDescribePVC
+	DescribePV            = report.KubernetesDescribePV
+	DescribeSC            = report.KubernetesDescribeSC
+	DescribeStatefulSet   = report.KubernetesDescribeStatefulSet
+	DescribeVSC           = report.KubernetesDescribeVSC
+	DescribeVSContent     = report.KubernetesDescribeVSContent
+	DeletePod             = report.KubernetesDeletePod
+	DeleteVolumeSnapshot  = report.KubernetesDeleteVolumeSnapshot
+	ScaleUp               = report.KubernetesScaleUp
+	ScaleDown             = report.KubernetesScaleDown
+	RestartDeployment     = report.KubernetesRestartDeployment
+	PauseDeployment       = report.KubernetesPauseDeployment
+	ResumeDeployment      = report.KubernetesResumeDeployment
+	RollbackDeployment    = report.KubernetesRollbackDeployment
+	CordonNode            = report.KubernetesCordonNode
+	UncordonNode          = report.KubernetesUncordonNode
+	DrainNode             = report.KubernetesDrainNode
 )
 
-// GetLogs is the control to get the logs for a kubernetes pod
+// GetLogs is the control to get the logs for a kubernetes pod. It supports
+// kubectl-logs-style follow, tailLines, sinceSeconds and previous options,
+// and an optional single-container selection, parsed off the request.
 func (r *Reporter) GetLogs(req xfer.Request, namespaceID, podID string, containerNames []string, _ schema.GroupKind) xfer.Response {
-	readCloser, err := r.client.GetLogs(namespaceID, podID, containerNames)
+	opts := parseLogsOptions(req)
+	if opts.ContainerName != "" {
+		containerNames = []string{opts.ContainerName}
+	}
+	if len(containerNames) == 0 {
+		return xfer.ResponseErrorf("No containers found for pod: %s", podID)
+	}
+
+	streams := map[string]io.ReadCloser{}
+	for _, containerName := range containerNames {
+		readCloser, err := r.client.GetLogs(namespaceID, podID, containerName, opts)
+		if err != nil {
+			for _, stream := range streams {
+				stream.Close()
+			}
+			return xfer.ResponseError(err)
+		}
+		streams[containerName] = readCloser
+	}
+	merged := mergeContainerLogs(streams)
+
+	readWriter := struct {
+		io.Reader
+		io.Writer
+	}{
+		merged,
+		ioutil.Discard,
+	}
+	id, pipe, err := controls.NewPipeFromEnds(nil, readWriter, r.pipes, req.AppID)
+	if err != nil {
+		merged.Close()
+		return xfer.ResponseError(err)
+	}
+	pipe.OnClose(func() {
+		merged.Close()
+	})
+	return xfer.Response{
+		Pipe: id,
+	}
+}
+
+// defaultExecCommand is used when a request doesn't specify one, matching
+// the shell kubectl exec falls back to.
+const defaultExecCommand = "/bin/sh"
+
+// ExecPod is the control to open an interactive shell in a pod's container,
+// bridging the SPDY exec stream to a scope pipe the same way GetLogs bridges
+// a log stream, except here the pipe's write side is the container's stdin
+// rather than a sink.
+func (r *Reporter) ExecPod(req xfer.Request, namespaceID, podID string, containerNames []string, _ schema.GroupKind) xfer.Response {
+	containerName := req.Args["container"]
+	if containerName == "" && len(containerNames) > 0 {
+		containerName = containerNames[0]
+	}
+	command := req.Args["command"]
+	if command == "" {
+		command = defaultExecCommand
+	}
+
+	executor, err := r.client.ExecPod(namespaceID, podID, containerName, command)
 	if err != nil {
 		return xfer.ResponseError(err)
 	}
 
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	go func() {
+		err := executor.Stream(remotecommand.StreamOptions{
+			Stdin:  stdinReader,
+			Stdout: stdoutWriter,
+			Stderr: stdoutWriter,
+			Tty:    true,
+		})
+		stdoutWriter.CloseWithError(err)
+	}()
+
 	readWriter := struct {
 		io.Reader
 		io.Writer
 	}{
-		readCloser,
-		ioutil.Discard,
+		stdoutReader,
+		stdinWriter,
 	}
 	id, pipe, err := controls.NewPipeFromEnds(nil, readWriter, r.pipes, req.AppID)
 	if err != nil {
+		stdinReader.Close()
+		stdoutWriter.Close()
 		return xfer.ResponseError(err)
 	}
 	pipe.OnClose(func() {
-		readCloser.Close()
+		stdinWriter.Close()
+		stdoutReader.Close()
 	})
 	return xfer.Response{
 		Pipe: id,
@@ -106,6 +195,17 @@ func (r *Reporter) createVolumeSnapshot(req xfer.Request, namespaceID, persisten
 	return xfer.Response{}
 }
 
+// restoreVolumeSnapshot creates a fresh PVC populated from an existing
+// VolumeSnapshot via the CSI dataSource, as an alternative to cloning through
+// the openebs snapshot-provisioner.
+func (r *Reporter) restoreVolumeSnapshot(req xfer.Request, namespaceID, volumeSnapshotID, newPVCName, storageClass, capacity string) xfer.Response {
+	err := r.client.RestoreVolumeSnapshot(namespaceID, volumeSnapshotID, newPVCName, storageClass, capacity)
+	if err != nil {
+		return xfer.ResponseError(err)
+	}
+	return xfer.Response{}
+}
+
 func (r *Reporter) deletePod(req xfer.Request, namespaceID, podID string, _ []string, _ schema.GroupKind) xfer.Response {
 	if err := r.client.DeletePod(namespaceID, podID); err != nil {
 		return xfer.ResponseError(err)
@@ -211,6 +311,31 @@ func (r *Reporter) CaptureVolumeSnapshot(f func(xfer.Request, string, string, st
 	}
 }
 
+// CaptureVolumeSnapshotForRestore is exported for testing. It returns name,
+// a generated PVC name, source storage class (empty for CSI snapshots) and
+// capacity of the volume snapshot being restored.
+func (r *Reporter) CaptureVolumeSnapshotForRestore(f func(xfer.Request, string, string, string, string, string) xfer.Response) func(xfer.Request) xfer.Response {
+	return func(req xfer.Request) xfer.Response {
+		uid, ok := report.ParseVolumeSnapshotNodeID(req.NodeID)
+		if !ok {
+			return xfer.ResponseErrorf("Invalid ID: %s", req.NodeID)
+		}
+		// find volume snapshot by UID
+		var volumeSnapshot VolumeSnapshot
+		r.client.WalkVolumeSnapshots(func(p VolumeSnapshot) error {
+			if p.UID() == uid {
+				volumeSnapshot = p
+			}
+			return nil
+		})
+		if volumeSnapshot == nil {
+			return xfer.ResponseErrorf("Volume snapshot not found: %s", uid)
+		}
+		newPVCName := volumeSnapshot.GetVolumeName() + "-restore"
+		return f(req, volumeSnapshot.Namespace(), volumeSnapshot.Name(), newPVCName, volumeSnapshot.GetStorageClass(), volumeSnapshot.GetCapacity())
+	}
+}
+
 // CaptureService is exported for testing
 func (r *Reporter) CaptureService(f func(xfer.Request, string, string, schema.GroupKind) xfer.Response) func(xfer.Request) xfer.Response {
 	return func(req xfer.Request) xfer.Response {
@@ -316,6 +441,48 @@ func (r *Reporter) CaptureStorageClass(f func(xfer.Request, string, string, sche
 	}
 }
 
+// CaptureVolumeSnapshotClass is exported for testing
+func (r *Reporter) CaptureVolumeSnapshotClass(f func(xfer.Request, string, string, schema.GroupKind) xfer.Response) func(xfer.Request) xfer.Response {
+	return func(req xfer.Request) xfer.Response {
+		name, ok := report.ParseVolumeSnapshotClassNodeID(req.NodeID)
+		if !ok {
+			return xfer.ResponseErrorf("Invalid ID: %s", req.NodeID)
+		}
+		var volumeSnapshotClass VolumeSnapshotClass
+		r.client.WalkVolumeSnapshotClasses(func(v VolumeSnapshotClass) error {
+			if v.Name() == name {
+				volumeSnapshotClass = v
+			}
+			return nil
+		})
+		if volumeSnapshotClass == nil {
+			return xfer.ResponseErrorf("VolumeSnapshotClass not found: %s", name)
+		}
+		return f(req, "", volumeSnapshotClass.Name(), ResourceMap["VolumeSnapshotClass"])
+	}
+}
+
+// CaptureVolumeSnapshotContent is exported for testing
+func (r *Reporter) CaptureVolumeSnapshotContent(f func(xfer.Request, string, string, schema.GroupKind) xfer.Response) func(xfer.Request) xfer.Response {
+	return func(req xfer.Request) xfer.Response {
+		name, ok := report.ParseVolumeSnapshotContentNodeID(req.NodeID)
+		if !ok {
+			return xfer.ResponseErrorf("Invalid ID: %s", req.NodeID)
+		}
+		var volumeSnapshotContent VolumeSnapshotContent
+		r.client.WalkVolumeSnapshotContents(func(v VolumeSnapshotContent) error {
+			if v.Name() == name {
+				volumeSnapshotContent = v
+			}
+			return nil
+		})
+		if volumeSnapshotContent == nil {
+			return xfer.ResponseErrorf("VolumeSnapshotContent not found: %s", name)
+		}
+		return f(req, "", volumeSnapshotContent.Name(), ResourceMap["VolumeSnapshotContent"])
+	}
+}
+
 // CapturePersistentVolume will return name, namespace and capacity of PVC
 func (r *Reporter) CapturePersistentVolume(f func(xfer.Request, string, string, schema.GroupKind) xfer.Response) func(xfer.Request) xfer.Response {
 	return func(req xfer.Request) xfer.Response {
@@ -338,6 +505,66 @@ func (r *Reporter) CapturePersistentVolume(f func(xfer.Request, string, string,
 	}
 }
 
+// CaptureNode is exported for testing
+func (r *Reporter) CaptureNode(f func(xfer.Request, string, schema.GroupKind) xfer.Response) func(xfer.Request) xfer.Response {
+	return func(req xfer.Request) xfer.Response {
+		uid, ok := report.ParseHostNodeID(req.NodeID)
+		if !ok {
+			return xfer.ResponseErrorf("Invalid ID: %s", req.NodeID)
+		}
+		var hostNode HostNode
+		r.client.WalkNodes(func(n HostNode) error {
+			if n.UID() == uid {
+				hostNode = n
+			}
+			return nil
+		})
+		if hostNode == nil {
+			return xfer.ResponseErrorf("Node not found: %s", uid)
+		}
+		return f(req, hostNode.Name(), ResourceMap["Node"])
+	}
+}
+
+// CordonNode marks a node unschedulable
+func (r *Reporter) CordonNode(req xfer.Request, nodeID string, _ schema.GroupKind) xfer.Response {
+	return xfer.ResponseError(r.client.CordonNode(nodeID))
+}
+
+// UncordonNode marks a node schedulable again
+func (r *Reporter) UncordonNode(req xfer.Request, nodeID string, _ schema.GroupKind) xfer.Response {
+	return xfer.ResponseError(r.client.UncordonNode(nodeID))
+}
+
+// DrainNode cordons a node, then evicts its pods one at a time, streaming
+// progress back through a scope pipe the same way GetLogs streams log lines,
+// since a drain can take a while on a busy node.
+func (r *Reporter) DrainNode(req xfer.Request, nodeID string, _ schema.GroupKind) xfer.Response {
+	readCloser, err := r.client.DrainNode(nodeID)
+	if err != nil {
+		return xfer.ResponseError(err)
+	}
+
+	readWriter := struct {
+		io.Reader
+		io.Writer
+	}{
+		readCloser,
+		ioutil.Discard,
+	}
+	id, pipe, err := controls.NewPipeFromEnds(nil, readWriter, r.pipes, req.AppID)
+	if err != nil {
+		readCloser.Close()
+		return xfer.ResponseError(err)
+	}
+	pipe.OnClose(func() {
+		readCloser.Close()
+	})
+	return xfer.Response{
+		Pipe: id,
+	}
+}
+
 // ScaleUp is the control to scale up a deployment
 func (r *Reporter) ScaleUp(req xfer.Request, namespace, id string, _ schema.GroupKind) xfer.Response {
 	return xfer.ResponseError(r.client.ScaleUp(report.Deployment, namespace, id))
@@ -348,24 +575,60 @@ func (r *Reporter) ScaleDown(req xfer.Request, namespace, id string, _ schema.Gr
 	return xfer.ResponseError(r.client.ScaleDown(report.Deployment, namespace, id))
 }
 
+// RestartDeployment triggers a rolling restart by patching
+// spec.template.metadata.annotations["kubectl.kubernetes.io/restartedAt"]
+// with the current time, the same trick `kubectl rollout restart` uses.
+func (r *Reporter) RestartDeployment(req xfer.Request, namespace, id string, _ schema.GroupKind) xfer.Response {
+	return xfer.ResponseError(r.client.RestartDeployment(namespace, id, time.Now().UTC().Format(time.RFC3339)))
+}
+
+// PauseDeployment is the control to pause a deployment's rollout
+func (r *Reporter) PauseDeployment(req xfer.Request, namespace, id string, _ schema.GroupKind) xfer.Response {
+	return xfer.ResponseError(r.client.PauseDeployment(namespace, id))
+}
+
+// ResumeDeployment is the control to resume a paused deployment's rollout
+func (r *Reporter) ResumeDeployment(req xfer.Request, namespace, id string, _ schema.GroupKind) xfer.Response {
+	return xfer.ResponseError(r.client.ResumeDeployment(namespace, id))
+}
+
+// RollbackDeployment is the control to roll a deployment back to its previous
+// ReplicaSet revision. There is no DeploymentRollback subresource in apps/v1,
+// so this is implemented client-side by updating the deployment with
+// spec.template set back to that of the ReplicaSet preceding the current one.
+func (r *Reporter) RollbackDeployment(req xfer.Request, namespace, id string, _ schema.GroupKind) xfer.Response {
+	return xfer.ResponseError(r.client.RollbackDeployment(namespace, id))
+}
+
 func (r *Reporter) registerControls() {
 	controls := map[string]xfer.ControlHandlerFunc{
-		CloneVolumeSnapshot:  r.CaptureVolumeSnapshot(r.cloneVolumeSnapshot),
-		CreateVolumeSnapshot: r.CapturePersistentVolumeClaim(r.createVolumeSnapshot),
-		GetLogs:              r.CapturePod(r.GetLogs),
-		DescribePod:          r.CapturePod(r.describePod),
-		DescribeService:      r.CaptureService(r.describe),
-		DescribeCronJob:      r.CaptureCronJob(r.describe),
-		DescribeDeployment:   r.CaptureDeployment(r.describe),
-		DescribeDaemonSet:    r.CaptureDaemonSet(r.describe),
-		DescribePVC:          r.CapturePersistentVolumeClaim(r.describePVC),
-		DescribePV:           r.CapturePersistentVolume(r.describe),
-		DescribeSC:           r.CaptureStorageClass(r.describe),
-		DescribeStatefulSet:  r.CaptureStatefulSet(r.describe),
-		DeletePod:            r.CapturePod(r.deletePod),
-		DeleteVolumeSnapshot: r.CaptureVolumeSnapshot(r.deleteVolumeSnapshot),
-		ScaleUp:              r.CaptureDeployment(r.ScaleUp),
-		ScaleDown:            r.CaptureDeployment(r.ScaleDown),
+		CloneVolumeSnapshot:   r.CaptureVolumeSnapshot(r.cloneVolumeSnapshot),
+		CreateVolumeSnapshot:  r.CapturePersistentVolumeClaim(r.createVolumeSnapshot),
+		RestoreVolumeSnapshot: r.CaptureVolumeSnapshotForRestore(r.restoreVolumeSnapshot),
+		GetLogs:               r.CapturePod(r.GetLogs),
+		ExecPod:               r.CapturePod(r.ExecPod),
+		DescribePod:           r.CapturePod(r.describePod),
+		DescribeService:       r.CaptureService(r.describe),
+		DescribeCronJob:       r.CaptureCronJob(r.describe),
+		DescribeDeployment:    r.CaptureDeployment(r.describe),
+		DescribeDaemonSet:     r.CaptureDaemonSet(r.describe),
+		DescribePVC:           r.CapturePersistentVolumeClaim(r.describePVC),
+		DescribePV:            r.CapturePersistentVolume(r.describe),
+		DescribeSC:            r.CaptureStorageClass(r.describe),
+		DescribeStatefulSet:   r.CaptureStatefulSet(r.describe),
+		DescribeVSC:           r.CaptureVolumeSnapshotClass(r.describe),
+		DescribeVSContent:     r.CaptureVolumeSnapshotContent(r.describe),
+		DeletePod:             r.CapturePod(r.deletePod),
+		DeleteVolumeSnapshot:  r.CaptureVolumeSnapshot(r.deleteVolumeSnapshot),
+		ScaleUp:               r.CaptureDeployment(r.ScaleUp),
+		ScaleDown:             r.CaptureDeployment(r.ScaleDown),
+		RestartDeployment:     r.CaptureDeployment(r.RestartDeployment),
+		PauseDeployment:       r.CaptureDeployment(r.PauseDeployment),
+		ResumeDeployment:      r.CaptureDeployment(r.ResumeDeployment),
+		RollbackDeployment:    r.CaptureDeployment(r.RollbackDeployment),
+		CordonNode:            r.CaptureNode(r.CordonNode),
+		UncordonNode:          r.CaptureNode(r.UncordonNode),
+		DrainNode:             r.CaptureNode(r.DrainNode),
 	}
 	r.handlerRegistry.Batch(nil, controls)
 }
@@ -374,11 +637,20 @@ func (r *Reporter) deregisterControls() {
 	controls := []string{
 		CloneVolumeSnapshot,
 		CreateVolumeSnapshot,
+		RestoreVolumeSnapshot,
 		GetLogs,
+		ExecPod,
 		DeletePod,
 		DeleteVolumeSnapshot,
 		ScaleUp,
 		ScaleDown,
+		RestartDeployment,
+		PauseDeployment,
+		ResumeDeployment,
+		RollbackDeployment,
+		CordonNode,
+		UncordonNode,
+		DrainNode,
 	}
 	r.handlerRegistry.Batch(controls, nil)
 }
@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	csisnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	csisnapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	fakecsisnapshot "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakekubernetes "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/weaveworks/scope/common/xfer"
+	"github.com/weaveworks/scope/report"
+)
+
+// fakeClient embeds Client so tests only need to override the Walk*/action
+// methods a given test actually exercises; anything else panics on a nil
+// dereference if accidentally called.
+type fakeClient struct {
+	Client
+	pods                  []Pod
+	volumeSnapshots       []VolumeSnapshot
+	volumeSnapshotClasses []VolumeSnapshotClass
+}
+
+func (f fakeClient) WalkPods(fn func(Pod) error) error {
+	for _, p := range f.pods {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f fakeClient) WalkVolumeSnapshots(fn func(VolumeSnapshot) error) error {
+	for _, s := range f.volumeSnapshots {
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f fakeClient) WalkVolumeSnapshotClasses(fn func(VolumeSnapshotClass) error) error {
+	for _, c := range f.volumeSnapshotClasses {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestPod(uid types.UID, namespace, name string, containers ...string) Pod {
+	spec := apiv1.PodSpec{}
+	for _, c := range containers {
+		spec.Containers = append(spec.Containers, apiv1.Container{Name: c})
+	}
+	return NewPod(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: uid, Namespace: namespace, Name: name},
+		Spec:       spec,
+	})
+}
+
+func newTestCSISnapshot(uid types.UID, namespace, name string) VolumeSnapshot {
+	return NewVolumeSnapshot(csiSnapshotSource{VolumeSnapshot: &csisnapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{UID: uid, Namespace: namespace, Name: name},
+	}})
+}
+
+func TestCapturePodFindsPodByUID(t *testing.T) {
+	pod := newTestPod("pod-uid", "default", "my-pod", "app", "sidecar")
+	r := &Reporter{client: fakeClient{pods: []Pod{pod}}}
+
+	var gotNamespace, gotName string
+	var gotContainers []string
+	handler := r.CapturePod(func(_ xfer.Request, namespace, name string, containers []string, _ schema.GroupKind) xfer.Response {
+		gotNamespace, gotName, gotContainers = namespace, name, containers
+		return xfer.Response{}
+	})
+
+	handler(xfer.Request{NodeID: string(report.MakePodNodeID("pod-uid"))})
+
+	if gotNamespace != "default" || gotName != "my-pod" {
+		t.Fatalf("got namespace=%q name=%q, want default/my-pod", gotNamespace, gotName)
+	}
+	if len(gotContainers) != 2 || gotContainers[0] != "app" || gotContainers[1] != "sidecar" {
+		t.Fatalf("got containers %v, want [app sidecar]", gotContainers)
+	}
+}
+
+func TestCaptureVolumeSnapshotForRestoreAppliesSuffixOnce(t *testing.T) {
+	snapshot := newTestCSISnapshot("snap-uid", "default", "my-snapshot")
+	r := &Reporter{client: fakeClient{volumeSnapshots: []VolumeSnapshot{snapshot}}}
+
+	var gotNewPVCName string
+	handler := r.CaptureVolumeSnapshotForRestore(func(_ xfer.Request, _, _, newPVCName, _, _ string) xfer.Response {
+		gotNewPVCName = newPVCName
+		return xfer.Response{}
+	})
+
+	handler(xfer.Request{NodeID: string(report.MakeVolumeSnapshotNodeID("snap-uid"))})
+
+	if want := "my-snapshot-restore"; gotNewPVCName != want {
+		t.Fatalf("got new PVC name %q, want %q (regression: -restore suffix must be applied exactly once)", gotNewPVCName, want)
+	}
+}
+
+// TestRestoreVolumeSnapshotRejectsEmptyCapacity is a regression test: a
+// CSI-backed snapshot whose Status.RestoreSize hasn't been populated yet
+// reports capacity "", which must surface as an error rather than panic the
+// probe via resource.MustParse.
+func TestRestoreVolumeSnapshotRejectsEmptyCapacity(t *testing.T) {
+	c := &client{}
+	if err := c.RestoreVolumeSnapshot("default", "my-snapshot", "my-snapshot-restore", "", ""); err == nil {
+		t.Fatal("expected an error for an empty capacity, got nil")
+	}
+}
+
+// TestRollbackDeploymentComparesRevisionsNumerically is a regression test:
+// once a deployment has rolled out past revision 9, a lexicographic
+// comparison of the "deployment.kubernetes.io/revision" annotation picks
+// "9" over "10" and "11", rolling back to the wrong ReplicaSet.
+func TestRollbackDeploymentComparesRevisionsNumerically(t *testing.T) {
+	labels := map[string]string{"app": "my-app"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-app",
+			Namespace:   "default",
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": "11"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+	replicaSetForRevision := func(revision string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "my-app-" + revision,
+				Namespace:   "default",
+				Labels:      labels,
+				Annotations: map[string]string{"deployment.kubernetes.io/revision": revision},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Template: apiv1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"revision": revision}},
+				},
+			},
+		}
+	}
+
+	clientset := fakekubernetes.NewSimpleClientset(
+		deployment,
+		replicaSetForRevision("9"),
+		replicaSetForRevision("10"),
+		replicaSetForRevision("11"),
+	)
+	c := &client{client: clientset}
+
+	if err := c.RollbackDeployment("default", "my-app"); err != nil {
+		t.Fatalf("RollbackDeployment returned error: %v", err)
+	}
+
+	updated, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch updated deployment: %v", err)
+	}
+	if got, want := updated.Spec.Template.Annotations["revision"], "10"; got != want {
+		t.Fatalf("rolled back to revision %q, want %q (regression: revisions must compare numerically, not lexicographically)", got, want)
+	}
+}
+
+// TestCaptureVolumeSnapshotClassFindsClassByName is a regression test:
+// VolumeSnapshotClass is cluster-scoped and keyed by name (not UID), so the
+// Capture lookup must compare against Name rather than the zero-value UID.
+func TestCaptureVolumeSnapshotClassFindsClassByName(t *testing.T) {
+	class := NewVolumeSnapshotClass(&csisnapshotv1.VolumeSnapshotClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-class"},
+	}, nil)
+	r := &Reporter{client: fakeClient{volumeSnapshotClasses: []VolumeSnapshotClass{class}}}
+
+	var gotName string
+	handler := r.CaptureVolumeSnapshotClass(func(_ xfer.Request, _, name string, _ schema.GroupKind) xfer.Response {
+		gotName = name
+		return xfer.Response{}
+	})
+
+	handler(xfer.Request{NodeID: string(report.MakeVolumeSnapshotClassNodeID("my-class"))})
+
+	if want := "my-class"; gotName != want {
+		t.Fatalf("got name %q, want %q (regression: lookup must compare by Name, not UID)", gotName, want)
+	}
+}
+
+// TestWalkVolumeSnapshotClassesDispatchesOnAPIGroup is a regression test:
+// WalkVolumeSnapshotClasses must dispatch on the detected snapshot API group
+// the same way WalkVolumeSnapshots does, instead of hardcoding SnapshotV1,
+// so clusters that only have the v1beta1 CRDs installed still work.
+func TestWalkVolumeSnapshotClassesDispatchesOnAPIGroup(t *testing.T) {
+	fakeCSI := fakecsisnapshot.NewSimpleClientset(&csisnapshotv1beta1.VolumeSnapshotClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-class"},
+		Driver:     "csi.example.com",
+	})
+	c := &client{
+		client:           fakekubernetes.NewSimpleClientset(),
+		csiSnapshot:      fakeCSI,
+		snapshotAPIGroup: SnapshotAPIGroupCSIV1Beta1,
+	}
+
+	var got []string
+	if err := c.WalkVolumeSnapshotClasses(func(v VolumeSnapshotClass) error {
+		got = append(got, v.Name())
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkVolumeSnapshotClasses returned error: %v", err)
+	}
+
+	if len(got) != 1 || got[0] != "my-class" {
+		t.Fatalf("got %v, want [my-class] (regression: must dispatch to SnapshotV1beta1 when that's the detected API group)", got)
+	}
+}
@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/weaveworks/scope/report"
+)
+
+// Deployment represents a kubernetes Deployment
+type Deployment interface {
+	Meta
+	GetNode(probeID string) report.Node
+}
+
+// deployment represents a kubernetes deployment
+type deployment struct {
+	*appsv1.Deployment
+	Meta
+}
+
+// NewDeployment returns a new Deployment
+func NewDeployment(d *appsv1.Deployment) Deployment {
+	return &deployment{Deployment: d, Meta: meta{d.ObjectMeta}}
+}
+
+// GetNode returns Deployment as Node
+func (d *deployment) GetNode(probeID string) report.Node {
+	return d.MetaNode(report.MakeDeploymentNodeID(d.UID())).WithLatests(map[string]string{
+		report.ControlProbeID: probeID,
+		NodeType:              "Deployment",
+		Name:                  d.GetName(),
+	}).WithLatestActiveControls(
+		ScaleUp, ScaleDown, RestartDeployment, PauseDeployment, ResumeDeployment, RollbackDeployment, DescribeDeployment,
+	)
+}
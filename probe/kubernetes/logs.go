@@ -0,0 +1,77 @@
+package kubernetes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/weaveworks/scope/common/xfer"
+)
+
+// LogsOptions mirrors the kubectl logs flags a user can pick from the UI.
+type LogsOptions struct {
+	Follow        bool
+	TailLines     int64
+	SinceSeconds  int64
+	Previous      bool
+	ContainerName string
+}
+
+// parseLogsOptions reads the kubectl-logs-style arguments off a GetLogs
+// request, defaulting to a one-shot, non-following dump when absent so old
+// clients that don't send them keep working.
+func parseLogsOptions(req xfer.Request) LogsOptions {
+	var opts LogsOptions
+	opts.Follow, _ = strconv.ParseBool(req.Args["follow"])
+	opts.TailLines, _ = strconv.ParseInt(req.Args["tailLines"], 10, 64)
+	opts.SinceSeconds, _ = strconv.ParseInt(req.Args["sinceSeconds"], 10, 64)
+	opts.Previous, _ = strconv.ParseBool(req.Args["previous"])
+	opts.ContainerName = req.Args["container"]
+	return opts
+}
+
+// mergeContainerLogs fans multiple per-container log streams into a single
+// ReadCloser, prefixing each line with "[container] " whenever more than one
+// container's stream is being merged so the streams stay distinguishable. Closing the result closes every underlying stream.
+func mergeContainerLogs(streams map[string]io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	prefixed := len(streams) > 1
+	done := make(chan struct{}, len(streams))
+	for name, stream := range streams {
+		go func(name string, stream io.ReadCloser) {
+			defer func() { done <- struct{}{} }()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if prefixed {
+					line = fmt.Sprintf("[%s] %s", name, line)
+				}
+				if _, err := fmt.Fprintln(pw, line); err != nil {
+					return
+				}
+			}
+		}(name, stream)
+	}
+	go func() {
+		for range streams {
+			<-done
+		}
+		pw.Close()
+	}()
+	return &mergedLogs{PipeReader: pr, streams: streams}
+}
+
+// mergedLogs closes every underlying per-container stream alongside the pipe
+// the merged output is read from.
+type mergedLogs struct {
+	*io.PipeReader
+	streams map[string]io.ReadCloser
+}
+
+func (m *mergedLogs) Close() error {
+	for _, stream := range m.streams {
+		stream.Close()
+	}
+	return m.PipeReader.Close()
+}
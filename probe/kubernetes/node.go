@@ -0,0 +1,38 @@
+package kubernetes
+
+import (
+	"strconv"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/weaveworks/scope/report"
+)
+
+// Unschedulable is the node latest key reporting spec.unschedulable.
+const Unschedulable = "unschedulable"
+
+// HostNode represents a kubernetes cluster Node, reported on the existing
+// Host topology node since a kubernetes Node is a Host.
+type HostNode interface {
+	Meta
+	GetNode(probeID string) report.Node
+}
+
+// hostNode represents a kubernetes cluster Node
+type hostNode struct {
+	*apiv1.Node
+	Meta
+}
+
+// NewHostNode returns a new HostNode
+func NewHostNode(n *apiv1.Node) HostNode {
+	return &hostNode{Node: n, Meta: meta{n.ObjectMeta}}
+}
+
+// GetNode merges the node's cordon/drain controls into its Host node
+func (n *hostNode) GetNode(probeID string) report.Node {
+	return n.MetaNode(report.MakeHostNodeID(n.UID())).WithLatests(map[string]string{
+		report.ControlProbeID: probeID,
+		Unschedulable:         strconv.FormatBool(n.Spec.Unschedulable),
+	}).WithLatestActiveControls(CordonNode, UncordonNode, DrainNode)
+}
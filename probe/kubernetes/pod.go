@@ -0,0 +1,44 @@
+package kubernetes
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/weaveworks/scope/report"
+)
+
+// Pod represents a kubernetes Pod
+type Pod interface {
+	Meta
+	GetNode(probeID string) report.Node
+	ContainerNames() []string
+}
+
+// pod represents a kubernetes pod
+type pod struct {
+	*apiv1.Pod
+	Meta
+}
+
+// NewPod returns a new Pod
+func NewPod(p *apiv1.Pod) Pod {
+	return &pod{Pod: p, Meta: meta{p.ObjectMeta}}
+}
+
+// ContainerNames returns the names of every container in the pod, used to
+// fan GetLogs and ExecPod out to (or select among) its containers.
+func (p *pod) ContainerNames() []string {
+	names := make([]string, 0, len(p.Spec.Containers))
+	for _, container := range p.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
+
+// GetNode returns Pod as Node
+func (p *pod) GetNode(probeID string) report.Node {
+	return p.MetaNode(report.MakePodNodeID(p.UID())).WithLatests(map[string]string{
+		report.ControlProbeID: probeID,
+		NodeType:              "Pod",
+		Name:                  p.GetName(),
+	}).WithLatestActiveControls(GetLogs, ExecPod, DeletePod, DescribePod)
+}
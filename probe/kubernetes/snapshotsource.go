@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	csisnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	csisnapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	volumesnapshotv1 "github.com/openebs/external-storage/snapshot/pkg/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+)
+
+// SnapshotAPIGroup identifies which VolumeSnapshot CRD group a cluster has
+// installed, so the probe can talk to whichever one is actually there.
+type SnapshotAPIGroup string
+
+const (
+	// SnapshotAPIGroupOpenEBS is the legacy openebs snapshot-provisioner API.
+	SnapshotAPIGroupOpenEBS SnapshotAPIGroup = "openebs.io/v1"
+	// SnapshotAPIGroupCSIV1Beta1 is the external-snapshotter v1beta1 API.
+	SnapshotAPIGroupCSIV1Beta1 SnapshotAPIGroup = "snapshot.storage.k8s.io/v1beta1"
+	// SnapshotAPIGroupCSIV1 is the external-snapshotter v1 API.
+	SnapshotAPIGroupCSIV1 SnapshotAPIGroup = "snapshot.storage.k8s.io/v1"
+)
+
+// DetectSnapshotAPIGroup queries cluster discovery for whichever VolumeSnapshot
+// CRD group is installed, preferring the CSI external-snapshotter APIs (used
+// by ceph-csi, vSphere CSI and most modern drivers) over the legacy openebs
+// one. It is called once at Reporter startup.
+func DetectSnapshotAPIGroup(disco discovery.DiscoveryInterface) (SnapshotAPIGroup, error) {
+	groups, err := disco.ServerGroups()
+	if err != nil {
+		return "", err
+	}
+	for _, group := range groups.Groups {
+		if group.Name != "snapshot.storage.k8s.io" {
+			continue
+		}
+		for _, version := range group.Versions {
+			if version.Version == "v1" {
+				return SnapshotAPIGroupCSIV1, nil
+			}
+		}
+		return SnapshotAPIGroupCSIV1Beta1, nil
+	}
+	return SnapshotAPIGroupOpenEBS, nil
+}
+
+// SnapshotSource abstracts over the different VolumeSnapshot CRDs a cluster
+// may have installed, so the rest of the probe can treat an openebs
+// snapshot-provisioner snapshot and a CSI external-snapshotter snapshot the
+// same way.
+type SnapshotSource interface {
+	ObjectMeta() metav1.ObjectMeta
+	VolumeName() string
+	Capacity() string
+	StorageClass() string
+	ReadyToUse() bool
+	RestoreSize() string
+	SourcePVC() string
+	SnapshotClassName() string
+	BoundVolumeSnapshotContentName() string
+}
+
+// openEBSSnapshotSource adapts the legacy openebs snapshot-provisioner
+// VolumeSnapshot type to SnapshotSource.
+type openEBSSnapshotSource struct {
+	*volumesnapshotv1.VolumeSnapshot
+}
+
+func (s openEBSSnapshotSource) ObjectMeta() metav1.ObjectMeta { return s.VolumeSnapshot.ObjectMeta }
+func (s openEBSSnapshotSource) VolumeName() string            { return s.VolumeSnapshot.GetVolumeName() }
+func (s openEBSSnapshotSource) Capacity() string              { return s.VolumeSnapshot.GetCapacity() }
+func (s openEBSSnapshotSource) StorageClass() string {
+	return s.VolumeSnapshot.Annotations[storageClassAnnotationKey]
+}
+
+// ReadyToUse, RestoreSize and SnapshotClassName have no openebs equivalent;
+// the openebs CRD predates all of them.
+func (s openEBSSnapshotSource) ReadyToUse() bool    { return true }
+func (s openEBSSnapshotSource) RestoreSize() string { return "" }
+
+// SourcePVC maps to the openebs spec's PersistentVolumeClaimName.
+func (s openEBSSnapshotSource) SourcePVC() string {
+	return s.VolumeSnapshot.Spec.PersistentVolumeClaimName
+}
+func (s openEBSSnapshotSource) SnapshotClassName() string { return "" }
+
+// BoundVolumeSnapshotContentName has no openebs equivalent; the legacy
+// snapshot-provisioner has no separate content object.
+func (s openEBSSnapshotSource) BoundVolumeSnapshotContentName() string { return "" }
+
+// csiSnapshotSource adapts the external-snapshotter v1 VolumeSnapshot type to
+// SnapshotSource.
+type csiSnapshotSource struct {
+	*csisnapshotv1.VolumeSnapshot
+}
+
+func (s csiSnapshotSource) ObjectMeta() metav1.ObjectMeta { return s.VolumeSnapshot.ObjectMeta }
+
+// VolumeName returns the snapshot's own name; CaptureVolumeSnapshotForRestore
+// is the one place that appends "-restore" to derive the new PVC's name, so
+// this must not also apply the suffix.
+func (s csiSnapshotSource) VolumeName() string { return s.VolumeSnapshot.Name }
+func (s csiSnapshotSource) Capacity() string {
+	if s.Status == nil || s.Status.RestoreSize == nil {
+		return ""
+	}
+	return s.Status.RestoreSize.String()
+}
+func (s csiSnapshotSource) StorageClass() string { return "" }
+func (s csiSnapshotSource) ReadyToUse() bool {
+	return s.Status != nil && s.Status.ReadyToUse != nil && *s.Status.ReadyToUse
+}
+func (s csiSnapshotSource) RestoreSize() string {
+	if s.Status == nil || s.Status.RestoreSize == nil {
+		return ""
+	}
+	return s.Status.RestoreSize.String()
+}
+func (s csiSnapshotSource) SourcePVC() string {
+	if s.Spec.Source.PersistentVolumeClaimName == nil {
+		return ""
+	}
+	return *s.Spec.Source.PersistentVolumeClaimName
+}
+func (s csiSnapshotSource) SnapshotClassName() string {
+	if s.Spec.VolumeSnapshotClassName == nil {
+		return ""
+	}
+	return *s.Spec.VolumeSnapshotClassName
+}
+func (s csiSnapshotSource) BoundVolumeSnapshotContentName() string {
+	if s.Status == nil || s.Status.BoundVolumeSnapshotContentName == nil {
+		return ""
+	}
+	return *s.Status.BoundVolumeSnapshotContentName
+}
+
+// csiSnapshotV1Beta1Source adapts the external-snapshotter v1beta1
+// VolumeSnapshot type to SnapshotSource, for clusters that have not yet
+// upgraded their CRDs to v1.
+type csiSnapshotV1Beta1Source struct {
+	*csisnapshotv1beta1.VolumeSnapshot
+}
+
+func (s csiSnapshotV1Beta1Source) ObjectMeta() metav1.ObjectMeta {
+	return s.VolumeSnapshot.ObjectMeta
+}
+
+// VolumeName returns the snapshot's own name; see csiSnapshotSource.VolumeName.
+func (s csiSnapshotV1Beta1Source) VolumeName() string { return s.VolumeSnapshot.Name }
+func (s csiSnapshotV1Beta1Source) Capacity() string {
+	if s.Status == nil || s.Status.RestoreSize == nil {
+		return ""
+	}
+	return s.Status.RestoreSize.String()
+}
+func (s csiSnapshotV1Beta1Source) StorageClass() string { return "" }
+func (s csiSnapshotV1Beta1Source) ReadyToUse() bool {
+	return s.Status != nil && s.Status.ReadyToUse != nil && *s.Status.ReadyToUse
+}
+func (s csiSnapshotV1Beta1Source) RestoreSize() string {
+	if s.Status == nil || s.Status.RestoreSize == nil {
+		return ""
+	}
+	return s.Status.RestoreSize.String()
+}
+func (s csiSnapshotV1Beta1Source) SourcePVC() string {
+	if s.Spec.Source.PersistentVolumeClaimName == nil {
+		return ""
+	}
+	return *s.Spec.Source.PersistentVolumeClaimName
+}
+func (s csiSnapshotV1Beta1Source) SnapshotClassName() string {
+	if s.Spec.VolumeSnapshotClassName == nil {
+		return ""
+	}
+	return *s.Spec.VolumeSnapshotClassName
+}
+func (s csiSnapshotV1Beta1Source) BoundVolumeSnapshotContentName() string {
+	if s.Status == nil || s.Status.BoundVolumeSnapshotContentName == nil {
+		return ""
+	}
+	return *s.Status.BoundVolumeSnapshotContentName
+}
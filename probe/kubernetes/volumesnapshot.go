@@ -1,32 +1,96 @@
 package kubernetes
 
 import (
-	volumesnapshotv1 "github.com/openebs/external-storage/snapshot/pkg/apis/volumesnapshot/v1"
+	"strconv"
+
 	"github.com/weaveworks/scope/report"
 )
 
+// storageClassAnnotationKey is the annotation an openebs VolumeSnapshot's
+// storage class is read from, so a clone or restore can reuse it. This
+// package only reads it; nothing here sets it on a snapshot.
+const storageClassAnnotationKey = "volume.beta.kubernetes.io/storage-class"
+
+// Node latest keys reported by VolumeSnapshot, populated from whichever CRD
+// group the cluster has installed.
+const (
+	ReadyToUse                  = "ready_to_use"
+	RestoreSize                 = "restore_size"
+	SourcePersistentVolumeClaim = "source_persistent_volume_claim"
+	VolumeSnapshotClassName     = "volume_snapshot_class_name"
+)
+
 // VolumeSnapshot represent kubernetes VolumeSnapshot interface
 type VolumeSnapshot interface {
 	Meta
 	GetNode(probeID string) report.Node
+	GetVolumeName() string
+	GetCapacity() string
+	GetStorageClass() string
 }
 
-// volumeSnapshot represents kubernetes volume snapshots
+// volumeSnapshot represents a kubernetes volume snapshot, regardless of
+// which VolumeSnapshot CRD it was read from. It delegates everything
+// CRD-specific to a SnapshotSource.
 type volumeSnapshot struct {
-	*volumesnapshotv1.VolumeSnapshot
+	SnapshotSource
 	Meta
 }
 
-// NewVolumeSnapshot returns new Volume Snapshot type
-func NewVolumeSnapshot(p *volumesnapshotv1.VolumeSnapshot) VolumeSnapshot {
-	return &volumeSnapshot{VolumeSnapshot: p, Meta: meta{p.ObjectMeta}}
+// NewVolumeSnapshot returns a new VolumeSnapshot backed by the given source,
+// dispatched by the caller to the CRD group the cluster actually has
+// installed (see DetectSnapshotAPIGroup).
+func NewVolumeSnapshot(s SnapshotSource) VolumeSnapshot {
+	return &volumeSnapshot{SnapshotSource: s, Meta: meta{s.ObjectMeta()}}
+}
+
+// GetVolumeName returns the base name for a PVC created from this snapshot:
+// a clone uses it as is, and CaptureVolumeSnapshotForRestore appends
+// "-restore" to it.
+func (p *volumeSnapshot) GetVolumeName() string {
+	return p.SnapshotSource.VolumeName()
+}
+
+// GetCapacity returns the capacity to request for a PVC created from this
+// snapshot. For a CSI snapshot this is its reported restore size, and it is
+// empty until the restore size has been populated.
+func (p *volumeSnapshot) GetCapacity() string {
+	return p.SnapshotSource.Capacity()
+}
+
+// GetStorageClass returns the storage class the snapshot's source volume was
+// provisioned with, so a restore can provision the new PVC the same way. Only
+// openebs snapshots record it; for CSI snapshots it is always empty.
+func (p *volumeSnapshot) GetStorageClass() string {
+	return p.SnapshotSource.StorageClass()
 }
 
 // GetNode returns VolumeSnapshot as Node
 func (p *volumeSnapshot) GetNode(probeID string) report.Node {
-	return p.MetaNode(report.MakeVolumeSnapshotNodeID(p.UID())).WithLatests(map[string]string{
+	latests := map[string]string{
 		report.ControlProbeID: probeID,
 		NodeType:              "Volume Snapshot",
 		Name:                  p.GetName(),
-	}).WithLatestActiveControls(DeleteVolumeSnapshot)
+		ReadyToUse:            strconv.FormatBool(p.SnapshotSource.ReadyToUse()),
+	}
+	if restoreSize := p.SnapshotSource.RestoreSize(); restoreSize != "" {
+		latests[RestoreSize] = restoreSize
+	}
+	if sourcePVC := p.SnapshotSource.SourcePVC(); sourcePVC != "" {
+		latests[SourcePersistentVolumeClaim] = sourcePVC
+	}
+	if snapshotClass := p.SnapshotSource.SnapshotClassName(); snapshotClass != "" {
+		latests[VolumeSnapshotClassName] = snapshotClass
+	}
+	node := p.MetaNode(report.MakeVolumeSnapshotNodeID(p.UID())).
+		WithLatests(latests).
+		WithLatestActiveControls(DeleteVolumeSnapshot, RestoreVolumeSnapshot)
+	// Draw the VolumeSnapshot -> VolumeSnapshotContent leg of the chain; the
+	// VolumeSnapshotContent -> VolumeSnapshotClass leg is drawn from the
+	// content node, and the VolumeSnapshotClass -> StorageClass leg from the
+	// class node, since only it knows the driver.
+	if contentName := p.SnapshotSource.BoundVolumeSnapshotContentName(); contentName != "" {
+		node = node.WithAdjacent(report.MakeVolumeSnapshotContentNodeID(contentName))
+	}
+	return node
 }
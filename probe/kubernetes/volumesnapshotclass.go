@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	csisnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"github.com/weaveworks/scope/report"
+)
+
+// Node latest keys reported by VolumeSnapshotClass.
+const (
+	Driver         = "driver"
+	DeletionPolicy = "deletion_policy"
+)
+
+// VolumeSnapshotClass represents a kubernetes VolumeSnapshotClass
+type VolumeSnapshotClass interface {
+	Meta
+	GetNode(probeID string) report.Node
+	GetDriver() string
+}
+
+// volumeSnapshotClass represents kubernetes volume snapshot classes
+type volumeSnapshotClass struct {
+	*csisnapshotv1.VolumeSnapshotClass
+	Meta
+	// storageClassNames holds the StorageClasses whose Provisioner matches
+	// this class's Driver, resolved by the caller since the match is a
+	// cluster-wide lookup the class itself can't perform.
+	storageClassNames []string
+}
+
+// NewVolumeSnapshotClass returns a new VolumeSnapshotClass. storageClassNames
+// are the StorageClasses provisioned by the same driver, used to draw the
+// VolumeSnapshotClass -> StorageClass edge.
+func NewVolumeSnapshotClass(p *csisnapshotv1.VolumeSnapshotClass, storageClassNames []string) VolumeSnapshotClass {
+	return &volumeSnapshotClass{VolumeSnapshotClass: p, Meta: meta{p.ObjectMeta}, storageClassNames: storageClassNames}
+}
+
+// GetDriver returns the CSI driver this class provisions snapshots with,
+// which is also the field that links it to a matching StorageClass.
+func (p *volumeSnapshotClass) GetDriver() string {
+	return p.Driver
+}
+
+// GetNode returns VolumeSnapshotClass as Node, adjacent to every
+// StorageClass provisioned by the same driver. VolumeSnapshotClass is
+// cluster-scoped, so its node ID is keyed by name rather than UID - the same
+// name a VolumeSnapshotContent's spec uses to reference it back.
+func (p *volumeSnapshotClass) GetNode(probeID string) report.Node {
+	node := p.MetaNode(report.MakeVolumeSnapshotClassNodeID(p.GetName())).WithLatests(map[string]string{
+		report.ControlProbeID: probeID,
+		NodeType:              "Volume Snapshot Class",
+		Name:                  p.GetName(),
+		Driver:                p.VolumeSnapshotClass.Driver,
+		DeletionPolicy:        string(p.VolumeSnapshotClass.DeletionPolicy),
+	}).WithLatestActiveControls(DescribeVSC)
+	for _, storageClassName := range p.storageClassNames {
+		node = node.WithAdjacent(report.MakeStorageClassNodeID(storageClassName))
+	}
+	return node
+}
@@ -0,0 +1,62 @@
+package kubernetes
+
+import (
+	csisnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"github.com/weaveworks/scope/report"
+)
+
+// Node latest keys reported by VolumeSnapshotContent.
+const (
+	VolumeSnapshotRef = "volume_snapshot_ref"
+)
+
+// VolumeSnapshotContent represents a kubernetes VolumeSnapshotContent
+type VolumeSnapshotContent interface {
+	Meta
+	GetNode(probeID string) report.Node
+	GetSnapshotClassName() string
+}
+
+// volumeSnapshotContent represents kubernetes volume snapshot contents
+type volumeSnapshotContent struct {
+	*csisnapshotv1.VolumeSnapshotContent
+	Meta
+}
+
+// NewVolumeSnapshotContent returns a new VolumeSnapshotContent
+func NewVolumeSnapshotContent(p *csisnapshotv1.VolumeSnapshotContent) VolumeSnapshotContent {
+	return &volumeSnapshotContent{VolumeSnapshotContent: p, Meta: meta{p.ObjectMeta}}
+}
+
+// GetSnapshotClassName returns the VolumeSnapshotClass this content was
+// provisioned from, which links it onward to a StorageClass via the driver.
+func (p *volumeSnapshotContent) GetSnapshotClassName() string {
+	if p.Spec.VolumeSnapshotClassName == nil {
+		return ""
+	}
+	return *p.Spec.VolumeSnapshotClassName
+}
+
+// GetNode returns VolumeSnapshotContent as Node, adjacent to the
+// VolumeSnapshotClass it was provisioned from (VolumeSnapshotContent is
+// cluster-scoped, so its node ID is keyed by name rather than UID - the same
+// name a VolumeSnapshot's status uses to reference it back).
+func (p *volumeSnapshotContent) GetNode(probeID string) report.Node {
+	latests := map[string]string{
+		report.ControlProbeID: probeID,
+		NodeType:              "Volume Snapshot Content",
+		Name:                  p.GetName(),
+		VolumeSnapshotRef:     p.Spec.VolumeSnapshotRef.Namespace + "/" + p.Spec.VolumeSnapshotRef.Name,
+	}
+	snapshotClass := p.GetSnapshotClassName()
+	if snapshotClass != "" {
+		latests[VolumeSnapshotClassName] = snapshotClass
+	}
+	node := p.MetaNode(report.MakeVolumeSnapshotContentNodeID(p.GetName())).
+		WithLatests(latests).
+		WithLatestActiveControls(DescribeVSContent)
+	if snapshotClass != "" {
+		node = node.WithAdjacent(report.MakeVolumeSnapshotClassNodeID(snapshotClass))
+	}
+	return node
+}
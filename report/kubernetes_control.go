@@ -0,0 +1,18 @@
+package report
+
+// Control IDs for the kubernetes probe's node control, rollout and draining
+// controls. Each is routed to a Reporter method by the handler registry in
+// probe/kubernetes/controls.go.
+const (
+	KubernetesExecPod               = "kubernetes_exec_pod"
+	KubernetesCordonNode            = "kubernetes_cordon_node"
+	KubernetesUncordonNode          = "kubernetes_uncordon_node"
+	KubernetesDrainNode             = "kubernetes_drain_node"
+	KubernetesRestartDeployment     = "kubernetes_restart_deployment"
+	KubernetesPauseDeployment       = "kubernetes_pause_deployment"
+	KubernetesResumeDeployment      = "kubernetes_resume_deployment"
+	KubernetesRollbackDeployment    = "kubernetes_rollback_deployment"
+	KubernetesRestoreVolumeSnapshot = "kubernetes_restore_volume_snapshot"
+	KubernetesDescribeVSC           = "kubernetes_describe_volume_snapshot_class"
+	KubernetesDescribeVSContent     = "kubernetes_describe_volume_snapshot_content"
+)
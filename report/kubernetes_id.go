@@ -0,0 +1,48 @@
+package report
+
+// Node ID helpers for the VolumeSnapshotClass and VolumeSnapshotContent
+// topologies, and for mapping a kubernetes cluster Node onto the existing
+// Host topology.
+
+// MakeVolumeSnapshotClassNodeID produces a VolumeSnapshotClass node ID from
+// the class's name. VolumeSnapshotClass is cluster-scoped, so its name is
+// already a stable, unique key, and using it (rather than its UID) lets a
+// VolumeSnapshotContent's spec.volumeSnapshotClassName resolve straight to
+// this ID without a separate name-to-UID lookup.
+func MakeVolumeSnapshotClassNodeID(name string) string {
+	return MakeID(name)
+}
+
+// ParseVolumeSnapshotClassNodeID parses a VolumeSnapshotClass node ID back
+// into the class's name.
+func ParseVolumeSnapshotClassNodeID(nodeID string) (name string, ok bool) {
+	return ParseNodeID(nodeID)
+}
+
+// MakeVolumeSnapshotContentNodeID produces a VolumeSnapshotContent node ID
+// from the content's name. VolumeSnapshotContent is cluster-scoped, so its
+// name is already a stable, unique key, and using it (rather than its UID)
+// lets a VolumeSnapshot's status.boundVolumeSnapshotContentName resolve
+// straight to this ID without a separate name-to-UID lookup.
+func MakeVolumeSnapshotContentNodeID(name string) string {
+	return MakeID(name)
+}
+
+// ParseVolumeSnapshotContentNodeID parses a VolumeSnapshotContent node ID
+// back into the content's name.
+func ParseVolumeSnapshotContentNodeID(nodeID string) (name string, ok bool) {
+	return ParseNodeID(nodeID)
+}
+
+// MakeHostNodeID produces the Host topology node ID for a kubernetes cluster
+// Node, keyed by the Node's UID so it merges into the Host node the host
+// probe reports for the same machine.
+func MakeHostNodeID(uid string) string {
+	return MakeID(uid)
+}
+
+// ParseHostNodeID parses a Host node ID produced by MakeHostNodeID back into
+// the originating kubernetes Node's UID.
+func ParseHostNodeID(nodeID string) (uid string, ok bool) {
+	return ParseNodeID(nodeID)
+}